@@ -0,0 +1,138 @@
+// Package apitype defines the wire types shared by the registry server and
+// its clients.
+package apitype
+
+// PushRequest is the request body for POST /v1/push.
+type PushRequest struct {
+	Name          string
+	Manifest      []byte
+	CompleteParts []CompletePart
+}
+
+// CompletePart reports the outcome of uploading one part of a blob.
+//
+// Handle is the opaque value the server returned in the corresponding
+// Requirement. Clients must echo it back unmodified; it is meaningless to
+// the client and its shape varies by backend (an S3 uploadId/partNumber
+// pair, a GCS resumable session URI, and so on).
+type CompletePart struct {
+	Handle string
+	ETag   string
+}
+
+// PushResponse is returned from POST /v1/push. If Requirements is
+// non-empty, the client must satisfy them and POST again, this time
+// including CompleteParts, before the manifest is committed.
+type PushResponse struct {
+	Requirements []Requirement
+}
+
+// Requirement describes a single PUT the client must perform to upload
+// blob data, or one part of it, before a push can complete.
+type Requirement struct {
+	Digest string
+	Offset int64
+	Size   int64
+	URL    string
+
+	// Handle is an opaque, backend-specific value the client must echo
+	// back in a CompletePart for multipart uploads. It is empty for
+	// single-part uploads.
+	Handle string
+}
+
+// Manifest is the client-supplied manifest for a model being pushed.
+type Manifest struct {
+	Layers []Layer
+}
+
+// Layer is a single content-addressed blob referenced by a Manifest.
+type Layer struct {
+	Digest string
+	Size   int64
+
+	// MediaType declares the kind of content the blob holds. It is
+	// required: the registry stores it as the blob's Content-Type so a
+	// client can tell a GGUF weight from an adapter or a projector
+	// without downloading it.
+	MediaType MediaType
+}
+
+// MediaType identifies the kind of content a Layer's blob holds.
+type MediaType string
+
+// Media types recognized by the registry.
+const (
+	MediaTypeModel     MediaType = "application/vnd.ollama.image.model"
+	MediaTypeAdapter   MediaType = "application/vnd.ollama.image.adapter"
+	MediaTypeProjector MediaType = "application/vnd.ollama.image.projector"
+	MediaTypeLicense   MediaType = "application/vnd.ollama.image.license"
+)
+
+// MediaTypeManifest is the Content-Type stored on committed manifests.
+const MediaTypeManifest = "application/vnd.ollama.manifest.v1+json"
+
+// PullRequest is the request body for POST /v1/pull.
+type PullRequest struct {
+	Name string
+}
+
+// PullResponse is returned from POST /v1/pull. Layers carries a presigned
+// GET URL for each layer in Manifest with a nonzero Size; zero-size layers
+// are left out, since a push never uploads them. The URLs accept a normal HTTP Range
+// header, so a client can resume a partial download of a large layer by
+// reissuing the same URL.
+type PullResponse struct {
+	Manifest Manifest
+	Digest   string
+	Layers   []PullLayer
+}
+
+// PullLayer is a single blob a client must download to complete a pull.
+type PullLayer struct {
+	Digest string
+	Size   int64
+	URL    string
+}
+
+// ResolveRequest is the request body for POST /v1/resolve.
+type ResolveRequest struct {
+	Name string
+}
+
+// ResolveResponse is returned from POST /v1/resolve. It is cheaper than a
+// pull for callers that only need to look up a tag's manifest and digest,
+// since it never mints blob URLs.
+type ResolveResponse struct {
+	Manifest Manifest
+	Digest   string
+}
+
+// RotateKeyRequest is the request body for POST /v1/admin/rotate-key. It
+// re-encrypts a single blob from the server's previous master key to its
+// current one; the server derives the actual per-blob keys itself, so no
+// key material ever appears on the wire.
+type RotateKeyRequest struct {
+	Digest string
+}
+
+// RotateKeyResponse is returned from POST /v1/admin/rotate-key once the
+// blob has been re-encrypted.
+type RotateKeyResponse struct {
+	Digest string
+}
+
+// UsageRequest is the request body for POST /v1/usage.
+type UsageRequest struct {
+	Tenant string
+}
+
+// UsageResponse is returned from POST /v1/usage. Period is the yyyymm
+// billing period the counters apply to; BytesStored and Requests are
+// zero if the backend doesn't support usage tracking at all.
+type UsageResponse struct {
+	Tenant      string
+	Period      string
+	BytesStored int64
+	Requests    int64
+}
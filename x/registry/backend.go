@@ -0,0 +1,175 @@
+package registry
+
+import (
+	"context"
+	"time"
+)
+
+// Backend abstracts the object-storage operations the registry needs to
+// serve pushes and pulls. Each supported cloud implements it as a driver;
+// Server is written against the interface so handlePush and handlePull
+// never see provider-specific types.
+type Backend interface {
+	// StatBlob reports whether the blob with the given digest already
+	// exists, returning ErrBlobNotFound if it does not.
+	StatBlob(ctx context.Context, digest string) (BlobInfo, error)
+
+	// PresignPut returns a URL the client can PUT the entire blob to
+	// directly, valid for ttl. contentType is baked into the string to
+	// sign, so the client is forced to send a matching Content-Type
+	// header for the PUT to be accepted. If enc.Mode is not
+	// EncryptionNone, the matching encryption headers are baked in too.
+	PresignPut(ctx context.Context, digest string, size int64, contentType string, enc Encryption, ttl time.Duration) (url string, err error)
+
+	// NewMultipartUpload begins a multipart upload for the blob with
+	// the given Content-Type and encryption settings, and returns an
+	// opaque upload ID understood by PresignPart and CompleteMultipart.
+	NewMultipartUpload(ctx context.Context, digest, contentType string, enc Encryption) (uploadID string, err error)
+
+	// PresignPart returns a URL for uploading one part of a multipart
+	// upload, along with an opaque handle the client must echo back in
+	// the matching apitype.CompletePart. For EncryptionSSEC, enc.Key
+	// must be the same key passed to NewMultipartUpload; S3 requires
+	// the customer key headers on every part PUT, not just the
+	// initiate call.
+	PresignPart(ctx context.Context, digest, uploadID string, partNumber int, enc Encryption, ttl time.Duration) (url, handle string, err error)
+
+	// CompleteMultipart finishes a multipart upload given the handles
+	// and ETags the client reported for each part.
+	CompleteMultipart(ctx context.Context, digest, uploadID string, parts []Part) error
+
+	// PutManifest stores the manifest bytes for name with meta baked in
+	// as the object's Content-Type and user metadata.
+	PutManifest(ctx context.Context, name string, data []byte, meta ManifestMetadata) error
+
+	// GetManifest returns the manifest bytes for name, or
+	// ErrManifestNotFound if none has been pushed.
+	GetManifest(ctx context.Context, name string) ([]byte, error)
+
+	// PresignGet returns a URL the client can GET the blob from
+	// directly, valid for ttl. For EncryptionSSEC, enc.Key must be the
+	// same key the blob was uploaded with.
+	PresignGet(ctx context.Context, digest string, enc Encryption, ttl time.Duration) (url string, err error)
+}
+
+// EncryptionMode selects how the registry encrypts blobs and manifests
+// at rest.
+type EncryptionMode string
+
+// Supported encryption modes.
+const (
+	EncryptionNone  EncryptionMode = "none"
+	EncryptionSSES3 EncryptionMode = "sse-s3" // bucket-managed AES-256
+	EncryptionSSEC  EncryptionMode = "sse-c"  // customer-provided, per-blob key
+)
+
+// Encryption carries the server's encryption mode and, for EncryptionSSEC,
+// the per-blob customer key the backend should use.
+type Encryption struct {
+	Mode EncryptionMode
+
+	// Key is the SSE-C key for this blob, derived by Server from its
+	// master key and the blob's digest. It is nil and unused unless
+	// Mode is EncryptionSSEC.
+	Key []byte
+}
+
+// KeyRotationBackend is implemented by backends that can re-encrypt a
+// stored blob in place under a new key, such as S3's CopyObject. Server
+// type-asserts for it when handling the key rotation admin endpoint.
+type KeyRotationBackend interface {
+	// RotateKey re-encrypts the blob at digest from oldEnc to newEnc.
+	RotateKey(ctx context.Context, digest string, oldEnc, newEnc Encryption) error
+}
+
+// BlobInfo describes a stored blob.
+type BlobInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// ManifestMetadata is baked into a manifest object when it is committed,
+// so tooling that lists the bucket directly can identify manifests and
+// the model/tag they belong to without downloading and parsing them.
+type ManifestMetadata struct {
+	ContentType  string
+	UserMetadata map[string]string
+	Encryption   Encryption
+}
+
+// Part is one completed part of a multipart upload, carrying back the
+// opaque handle the client echoed. Only the backend that minted Handle
+// knows how to interpret it.
+type Part struct {
+	Handle string
+	ETag   string
+}
+
+// LifecycleBackend is implemented by backends that can enforce storage
+// lifecycle rules natively, such as an S3-compatible bucket lifecycle
+// configuration. Server type-asserts for it at startup and during
+// reconciliation; backends that don't implement it simply skip both.
+type LifecycleBackend interface {
+	// EnsureLifecycle installs or updates the backend's native
+	// lifecycle rules to match cfg. It is safe to call repeatedly.
+	EnsureLifecycle(ctx context.Context, cfg LifecycleConfig) error
+
+	// ListBlobDigests returns the digest of every blob currently
+	// stored.
+	ListBlobDigests(ctx context.Context) ([]string, error)
+
+	// ListReferencedDigests returns the digest of every blob
+	// referenced by any manifest currently stored.
+	ListReferencedDigests(ctx context.Context) (map[string]struct{}, error)
+
+	// TagOrphan marks a blob as unreferenced so the lifecycle rule
+	// installed by EnsureLifecycle can sweep it once it's at least
+	// OrphanBlobTTL old (see LifecycleConfig.OrphanBlobTTL).
+	TagOrphan(ctx context.Context, digest string) error
+
+	// UntagOrphan clears the orphan tag set by TagOrphan, if any. It is
+	// a no-op if digest isn't tagged. The reconciler calls it for every
+	// currently-referenced blob, so one that was tagged orphan in a
+	// window before its manifest landed doesn't get swept later by the
+	// lifecycle rule despite now being referenced.
+	UntagOrphan(ctx context.Context, digest string) error
+}
+
+// LifecycleConfig controls how long abandoned uploads and unreferenced
+// blobs are allowed to sit in the backend before being swept.
+type LifecycleConfig struct {
+	// AbortMultipartAfter is how long an incomplete multipart upload
+	// may sit before it is aborted and its parts freed.
+	AbortMultipartAfter time.Duration
+
+	// OrphanBlobTTL is the S3 lifecycle rule's Expiration.Days for a
+	// blob tagged as orphaned by TagOrphan. S3 evaluates Expiration.Days
+	// from the object's creation date, not from when it was tagged, so
+	// this is not "how long an orphaned blob may sit before deletion":
+	// a blob that was referenced for months and only just became
+	// orphaned can be swept on the very next lifecycle pass, long
+	// before OrphanBlobTTL has elapsed since it was tagged. It's really
+	// a minimum blob age before an orphan tag becomes eligible for
+	// sweeping at all.
+	OrphanBlobTTL time.Duration
+}
+
+var (
+	// ErrBlobNotFound is returned by StatBlob when the requested digest
+	// has not been pushed. PresignGet does not check that the blob
+	// exists.
+	ErrBlobNotFound = backendError("blob not found")
+
+	// ErrManifestNotFound is returned by GetManifest when no manifest
+	// has been pushed for a name.
+	ErrManifestNotFound = backendError("manifest not found")
+
+	// ErrContentTypeMismatch is returned when a completed upload's
+	// stored Content-Type does not match what the manifest declared
+	// for that layer.
+	ErrContentTypeMismatch = backendError("stored content type does not match manifest")
+)
+
+type backendError string
+
+func (e backendError) Error() string { return string(e) }
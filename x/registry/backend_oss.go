@@ -0,0 +1,206 @@
+package registry
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// OSSConfig configures an OSSBackend.
+type OSSConfig struct {
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+}
+
+// OSSBackend is the Backend driver for Aliyun Object Storage Service. Its
+// multipart protocol mirrors S3's closely enough that this driver follows
+// the same shape as S3Backend.
+type OSSBackend struct {
+	bucket *oss.Bucket
+}
+
+// NewOSSBackend returns a Backend backed by the given OSS bucket.
+func NewOSSBackend(cfg OSSConfig) (*OSSBackend, error) {
+	client, err := oss.New(cfg.Endpoint, cfg.AccessKey, cfg.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := client.Bucket(cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &OSSBackend{bucket: bucket}, nil
+}
+
+func (b *OSSBackend) blobKey(digest string) string { return "blobs/" + digest }
+
+func (b *OSSBackend) StatBlob(ctx context.Context, digest string) (BlobInfo, error) {
+	header, err := b.bucket.GetObjectDetailedMeta(b.blobKey(digest))
+	if err != nil {
+		if ossIsNoSuchKey(err) {
+			return BlobInfo{}, ErrBlobNotFound
+		}
+		return BlobInfo{}, err
+	}
+	size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return BlobInfo{}, err
+	}
+	return BlobInfo{Size: size, ContentType: header.Get("Content-Type")}, nil
+}
+
+func (b *OSSBackend) PresignPut(ctx context.Context, digest string, size int64, contentType string, enc Encryption, ttl time.Duration) (string, error) {
+	opts := []oss.Option{
+		oss.ContentType(contentType),
+		oss.Meta("ollama-digest", digest),
+	}
+	sseOpts, err := ossSSEOptions(enc)
+	if err != nil {
+		return "", err
+	}
+	opts = append(opts, sseOpts...)
+	return b.bucket.SignURL(b.blobKey(digest), oss.HTTPPut, int64(ttl.Seconds()), opts...)
+}
+
+func (b *OSSBackend) NewMultipartUpload(ctx context.Context, digest, contentType string, enc Encryption) (string, error) {
+	opts := []oss.Option{
+		oss.ContentType(contentType),
+		oss.Meta("ollama-digest", digest),
+	}
+	sseOpts, err := ossSSEOptions(enc)
+	if err != nil {
+		return "", err
+	}
+	opts = append(opts, sseOpts...)
+	imur, err := b.bucket.InitiateMultipartUpload(b.blobKey(digest), opts...)
+	if err != nil {
+		return "", err
+	}
+	return imur.UploadID, nil
+}
+
+func (b *OSSBackend) PresignPart(ctx context.Context, digest, uploadID string, partNumber int, enc Encryption, ttl time.Duration) (string, string, error) {
+	params := map[string]interface{}{
+		"uploadId":   uploadID,
+		"partNumber": strconv.Itoa(partNumber),
+	}
+	opts := []oss.Option{oss.AddParam(params)}
+	sseOpts, err := ossSSEOptions(enc)
+	if err != nil {
+		return "", "", err
+	}
+	opts = append(opts, sseOpts...)
+	u, err := b.bucket.SignURL(b.blobKey(digest), oss.HTTPPut, int64(ttl.Seconds()), opts...)
+	if err != nil {
+		return "", "", err
+	}
+	return u, encodeHandle(s3PartHandle{PartNumber: partNumber}), nil
+}
+
+// ossSSEOptions folds the headers a signed OSS request must carry for enc
+// into request options, the same way S3's addSSEHeaders does, so the
+// client is forced to send matching headers for the signature to
+// validate.
+func ossSSEOptions(enc Encryption) ([]oss.Option, error) {
+	switch enc.Mode {
+	case "", EncryptionNone:
+		return nil, nil
+	case EncryptionSSES3:
+		return []oss.Option{oss.ServerSideEncryption("AES256")}, nil
+	case EncryptionSSEC:
+		if len(enc.Key) != 32 {
+			return nil, fmt.Errorf("registry: sse-c key must be 32 bytes, got %d", len(enc.Key))
+		}
+		sum := md5.Sum(enc.Key)
+		return []oss.Option{
+			oss.AddHeader("x-oss-server-side-encryption-customer-algorithm", "AES256"),
+			oss.AddHeader("x-oss-server-side-encryption-customer-key", base64.StdEncoding.EncodeToString(enc.Key)),
+			oss.AddHeader("x-oss-server-side-encryption-customer-key-MD5", base64.StdEncoding.EncodeToString(sum[:])),
+		}, nil
+	default:
+		return nil, fmt.Errorf("registry: unknown encryption mode %q", enc.Mode)
+	}
+}
+
+func (b *OSSBackend) CompleteMultipart(ctx context.Context, digest, uploadID string, parts []Part) error {
+	imur := oss.InitiateMultipartUploadResult{
+		Bucket:   b.bucket.BucketName,
+		Key:      b.blobKey(digest),
+		UploadID: uploadID,
+	}
+	completed := make([]oss.UploadPart, 0, len(parts))
+	for _, p := range parts {
+		h, err := decodeS3PartHandle(p.Handle)
+		if err != nil {
+			return err
+		}
+		completed = append(completed, oss.UploadPart{PartNumber: h.PartNumber, ETag: p.ETag})
+	}
+	_, err := b.bucket.CompleteMultipartUpload(imur, completed)
+	return err
+}
+
+func (b *OSSBackend) manifestKey(name string) string { return "manifests/" + name }
+
+func (b *OSSBackend) PutManifest(ctx context.Context, name string, data []byte, meta ManifestMetadata) error {
+	opts := []oss.Option{oss.ContentType(meta.ContentType)}
+	for k, v := range meta.UserMetadata {
+		opts = append(opts, oss.Meta(k, v))
+	}
+	sseOpts, err := ossSSEOptions(meta.Encryption)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, sseOpts...)
+	return b.bucket.PutObject(b.manifestKey(name), bytesReader(data), opts...)
+}
+
+func (b *OSSBackend) GetManifest(ctx context.Context, name string) ([]byte, error) {
+	r, err := b.bucket.GetObject(b.manifestKey(name))
+	if err != nil {
+		if ossIsNoSuchKey(err) {
+			return nil, ErrManifestNotFound
+		}
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (b *OSSBackend) PresignGet(ctx context.Context, digest string, enc Encryption, ttl time.Duration) (string, error) {
+	sseOpts, err := ossSSEOptions(enc)
+	if err != nil {
+		return "", err
+	}
+	return b.bucket.SignURL(b.blobKey(digest), oss.HTTPGet, int64(ttl.Seconds()), sseOpts...)
+}
+
+// RotateKey re-encrypts a blob under newEnc by copying it onto itself,
+// the same way S3Backend does, since OSS's CopyObject also supports
+// changing server-side encryption in place.
+func (b *OSSBackend) RotateKey(ctx context.Context, digest string, oldEnc, newEnc Encryption) error {
+	srcOpts, err := ossSSEOptions(oldEnc)
+	if err != nil {
+		return err
+	}
+	dstOpts, err := ossSSEOptions(newEnc)
+	if err != nil {
+		return err
+	}
+	key := b.blobKey(digest)
+	_, err = b.bucket.CopyObject(key, key, append(srcOpts, dstOpts...)...)
+	return err
+}
+
+func ossIsNoSuchKey(err error) bool {
+	sErr, ok := err.(oss.ServiceError)
+	return ok && sErr.Code == "NoSuchKey"
+}
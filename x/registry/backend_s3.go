@@ -0,0 +1,252 @@
+package registry
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// S3Config configures an S3Backend.
+type S3Config struct {
+	Endpoint string
+	Bucket   string
+	Access   string
+	Secret   string
+	Secure   bool
+}
+
+// S3Backend is the Backend driver for S3-compatible object stores,
+// including MinIO.
+type S3Backend struct {
+	bucket string
+	client *minio.Client
+	core   *minio.Core
+}
+
+// NewS3Backend connects to an S3-compatible endpoint and returns a Backend
+// backed by it.
+func NewS3Backend(cfg S3Config) (*S3Backend, error) {
+	opts := &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.Access, cfg.Secret, ""),
+		Secure: cfg.Secure,
+	}
+	client, err := minio.New(cfg.Endpoint, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Backend{
+		bucket: cfg.Bucket,
+		client: client,
+		core:   &minio.Core{Client: client},
+	}, nil
+}
+
+// digestMetaHeader is the user-metadata header the registry forces onto
+// every presigned blob PUT so stored objects can be cross-checked against
+// the digest their key already encodes.
+const digestMetaHeader = "X-Amz-Meta-Ollama-Digest"
+
+func (b *S3Backend) blobKey(digest string) string { return path.Join("blobs", digest) }
+
+func (b *S3Backend) StatBlob(ctx context.Context, digest string) (BlobInfo, error) {
+	info, err := b.client.StatObject(ctx, b.bucket, b.blobKey(digest), minio.StatObjectOptions{})
+	if err != nil {
+		if isS3NoSuchKey(err) {
+			return BlobInfo{}, ErrBlobNotFound
+		}
+		return BlobInfo{}, err
+	}
+	return BlobInfo{Size: info.Size, ContentType: info.ContentType}, nil
+}
+
+func (b *S3Backend) PresignPut(ctx context.Context, digest string, size int64, contentType string, enc Encryption, ttl time.Duration) (string, error) {
+	headers := http.Header{
+		"Content-Type":   []string{contentType},
+		digestMetaHeader: []string{digest},
+	}
+	if err := addSSEHeaders(headers, enc); err != nil {
+		return "", err
+	}
+	u, err := b.client.PresignHeader(ctx, "PUT", b.bucket, b.blobKey(digest), ttl, url.Values{}, headers)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (b *S3Backend) NewMultipartUpload(ctx context.Context, digest, contentType string, enc Encryption) (string, error) {
+	sse, err := sseOption(enc)
+	if err != nil {
+		return "", err
+	}
+	return b.core.NewMultipartUpload(ctx, b.bucket, b.blobKey(digest), minio.PutObjectOptions{
+		ContentType:          contentType,
+		UserMetadata:         map[string]string{"ollama-digest": digest},
+		ServerSideEncryption: sse,
+	})
+}
+
+// s3PartHandle is the opaque handle encoded into part Requirements. It
+// round-trips the part number, the only piece of information the client
+// can't be trusted to report honestly back to CompleteMultipart.
+type s3PartHandle struct {
+	PartNumber int
+}
+
+func (b *S3Backend) PresignPart(ctx context.Context, digest, uploadID string, partNumber int, enc Encryption, ttl time.Duration) (string, string, error) {
+	values := url.Values{
+		"uploadId":   []string{uploadID},
+		"partNumber": []string{strconv.Itoa(partNumber)},
+	}
+	headers := http.Header{}
+	if err := addSSEHeaders(headers, enc); err != nil {
+		return "", "", err
+	}
+	u, err := b.client.PresignHeader(ctx, "PUT", b.bucket, b.blobKey(digest), ttl, values, headers)
+	if err != nil {
+		return "", "", err
+	}
+	return u.String(), encodeS3PartHandle(s3PartHandle{PartNumber: partNumber}), nil
+}
+
+func (b *S3Backend) CompleteMultipart(ctx context.Context, digest, uploadID string, parts []Part) error {
+	completed := make([]minio.CompletePart, 0, len(parts))
+	for _, p := range parts {
+		h, err := decodeS3PartHandle(p.Handle)
+		if err != nil {
+			return err
+		}
+		completed = append(completed, minio.CompletePart{
+			PartNumber: h.PartNumber,
+			ETag:       p.ETag,
+		})
+	}
+	_, err := b.core.CompleteMultipartUpload(ctx, b.bucket, b.blobKey(digest), uploadID, completed, minio.PutObjectOptions{})
+	if err != nil {
+		var e minio.ErrorResponse
+		if errors.As(err, &e) && e.Code == "NoSuchUpload" {
+			return fmt.Errorf("unknown uploadId %q: %w", uploadID, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (b *S3Backend) manifestKey(name string) string { return path.Join("manifests", name) }
+
+func (b *S3Backend) PutManifest(ctx context.Context, name string, data []byte, meta ManifestMetadata) error {
+	sse, err := sseOption(meta.Encryption)
+	if err != nil {
+		return err
+	}
+	_, err = b.client.PutObject(ctx, b.bucket, b.manifestKey(name), bytesReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType:          meta.ContentType,
+		UserMetadata:         meta.UserMetadata,
+		ServerSideEncryption: sse,
+	})
+	return err
+}
+
+func (b *S3Backend) GetManifest(ctx context.Context, name string) ([]byte, error) {
+	obj, err := b.client.GetObject(ctx, b.bucket, b.manifestKey(name), minio.GetObjectOptions{})
+	if err != nil {
+		if isS3NoSuchKey(err) {
+			return nil, ErrManifestNotFound
+		}
+		return nil, err
+	}
+	defer obj.Close()
+	data, err := readAll(obj)
+	if err != nil {
+		if isS3NoSuchKey(err) {
+			return nil, ErrManifestNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (b *S3Backend) PresignGet(ctx context.Context, digest string, enc Encryption, ttl time.Duration) (string, error) {
+	headers := http.Header{}
+	if err := addSSEHeaders(headers, enc); err != nil {
+		return "", err
+	}
+	u, err := b.client.PresignHeader(ctx, "GET", b.bucket, b.blobKey(digest), ttl, url.Values{}, headers)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func isS3NoSuchKey(err error) bool {
+	var e minio.ErrorResponse
+	return errors.As(err, &e) && (e.Code == "NoSuchKey" || e.Code == "NoSuchUpload")
+}
+
+// sseOption translates an Encryption into the minio-go encrypt.ServerSide
+// value its PutObjectOptions.ServerSideEncryption field expects.
+func sseOption(enc Encryption) (encrypt.ServerSide, error) {
+	switch enc.Mode {
+	case "", EncryptionNone:
+		return nil, nil
+	case EncryptionSSES3:
+		return encrypt.NewSSE(), nil
+	case EncryptionSSEC:
+		return encrypt.NewSSEC(enc.Key)
+	default:
+		return nil, fmt.Errorf("registry: unknown encryption mode %q", enc.Mode)
+	}
+}
+
+// RotateKey re-encrypts a blob under newEnc by copying it onto itself,
+// exploiting S3's support for in-place CopyObject re-encryption.
+func (b *S3Backend) RotateKey(ctx context.Context, digest string, oldEnc, newEnc Encryption) error {
+	srcSSE, err := sseOption(oldEnc)
+	if err != nil {
+		return err
+	}
+	dstSSE, err := sseOption(newEnc)
+	if err != nil {
+		return err
+	}
+	key := b.blobKey(digest)
+	src := minio.CopySrcOptions{Bucket: b.bucket, Object: key, Encryption: srcSSE}
+	dst := minio.CopyDestOptions{Bucket: b.bucket, Object: key, Encryption: dstSSE}
+	_, err = b.client.CopyObject(ctx, dst, src)
+	return err
+}
+
+// addSSEHeaders folds the headers a presigned request must carry for enc
+// into headers, using PresignHeader's extraHeaders so they're folded into
+// the signed-headers list and actually force the uploading/downloading
+// client to send matching headers; S3 silently ignores same-named query
+// parameters on the request itself.
+func addSSEHeaders(headers http.Header, enc Encryption) error {
+	switch enc.Mode {
+	case "", EncryptionNone:
+	case EncryptionSSES3:
+		headers.Set("x-amz-server-side-encryption", "AES256")
+	case EncryptionSSEC:
+		if len(enc.Key) != 32 {
+			return fmt.Errorf("registry: sse-c key must be 32 bytes, got %d", len(enc.Key))
+		}
+		sum := md5.Sum(enc.Key)
+		headers.Set("x-amz-server-side-encryption-customer-algorithm", "AES256")
+		headers.Set("x-amz-server-side-encryption-customer-key", base64.StdEncoding.EncodeToString(enc.Key))
+		headers.Set("x-amz-server-side-encryption-customer-key-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	default:
+		return fmt.Errorf("registry: unknown encryption mode %q", enc.Mode)
+	}
+	return nil
+}
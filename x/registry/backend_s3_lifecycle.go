@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/minio/minio-go/v7/pkg/tags"
+)
+
+// orphanTagKey marks a blob that the reconciler found no manifest
+// referencing, so the lifecycle rule installed by EnsureLifecycle knows
+// to sweep it once it's old enough (see LifecycleConfig.OrphanBlobTTL).
+const orphanTagKey = "ollama-orphan"
+
+// EnsureLifecycle installs a bucket lifecycle configuration with two
+// rules: one that aborts incomplete multipart uploads under blobs/ after
+// cfg.AbortMultipartAfter, and one that expires blobs tagged orphaned by
+// TagOrphan once cfg.OrphanBlobTTL has elapsed since the object's
+// creation, which is not necessarily since it was tagged; see
+// LifecycleConfig.OrphanBlobTTL.
+func (b *S3Backend) EnsureLifecycle(ctx context.Context, cfg LifecycleConfig) error {
+	abortDays := max(1, int(cfg.AbortMultipartAfter.Hours()/24))
+	orphanDays := max(1, int(cfg.OrphanBlobTTL.Hours()/24))
+
+	lc := lifecycle.NewConfiguration()
+	lc.Rules = []lifecycle.Rule{
+		{
+			ID:         "ollama-abort-incomplete-multipart",
+			Status:     "Enabled",
+			RuleFilter: lifecycle.Filter{Prefix: "blobs/"},
+			AbortIncompleteMultipartUpload: lifecycle.AbortIncompleteMultipartUpload{
+				DaysAfterInitiation: lifecycle.ExpirationDays(abortDays),
+			},
+		},
+		{
+			ID:     "ollama-expire-orphan-blobs",
+			Status: "Enabled",
+			RuleFilter: lifecycle.Filter{
+				Prefix: "blobs/",
+				Tag:    lifecycle.Tag{Key: orphanTagKey, Value: "true"},
+			},
+			Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(orphanDays)},
+		},
+	}
+	return b.client.SetBucketLifecycle(ctx, b.bucket, lc)
+}
+
+// ListBlobDigests lists every object under blobs/ and returns its digest
+// (the key with the prefix stripped).
+func (b *S3Backend) ListBlobDigests(ctx context.Context) ([]string, error) {
+	var digests []string
+	for obj := range b.client.ListObjects(ctx, b.bucket, minio.ListObjectsOptions{Prefix: "blobs/"}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		digests = append(digests, obj.Key[len("blobs/"):])
+	}
+	return digests, nil
+}
+
+// ListReferencedDigests reads every stored manifest and returns the set
+// of blob digests any of them reference.
+func (b *S3Backend) ListReferencedDigests(ctx context.Context) (map[string]struct{}, error) {
+	referenced := make(map[string]struct{})
+	for obj := range b.client.ListObjects(ctx, b.bucket, minio.ListObjectsOptions{Prefix: "manifests/"}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		data, err := b.getObject(ctx, obj.Key)
+		if err != nil {
+			return nil, err
+		}
+		var m struct {
+			Layers []struct{ Digest string }
+		}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		for _, l := range m.Layers {
+			referenced[l.Digest] = struct{}{}
+		}
+	}
+	return referenced, nil
+}
+
+// TagOrphan sets the orphan tag on a blob so the lifecycle rule installed
+// by EnsureLifecycle will expire it.
+func (b *S3Backend) TagOrphan(ctx context.Context, digest string) error {
+	t, err := tags.NewTags(map[string]string{orphanTagKey: "true"}, true)
+	if err != nil {
+		return err
+	}
+	return b.client.PutObjectTagging(ctx, b.bucket, b.blobKey(digest), t, minio.PutObjectTaggingOptions{})
+}
+
+// UntagOrphan clears the orphan tag from a blob, if it's set. Removing a
+// tag that isn't present is a no-op, so callers don't need to check
+// whether digest was ever tagged first.
+func (b *S3Backend) UntagOrphan(ctx context.Context, digest string) error {
+	return b.client.RemoveObjectTagging(ctx, b.bucket, b.blobKey(digest), minio.RemoveObjectTaggingOptions{})
+}
+
+func (b *S3Backend) getObject(ctx context.Context, key string) ([]byte, error) {
+	obj, err := b.client.GetObject(ctx, b.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(obj); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
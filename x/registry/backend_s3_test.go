@@ -0,0 +1,108 @@
+package registry
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testS3Backend(t *testing.T) *S3Backend {
+	t.Helper()
+	b, err := NewS3Backend(S3Config{
+		Endpoint: "s3.example.com",
+		Bucket:   "models",
+		Access:   "access",
+		Secret:   "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Backend: %v", err)
+	}
+	return b
+}
+
+// signedHeaders extracts the lowercase header names PresignHeader folded
+// into the request's signature from a presigned URL's query string.
+func signedHeaders(t *testing.T, rawURL string) []string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("parse presigned url: %v", err)
+	}
+	v := u.Query().Get("X-Amz-SignedHeaders")
+	if v == "" {
+		t.Fatalf("presigned url has no X-Amz-SignedHeaders: %s", rawURL)
+	}
+	return strings.Split(v, ";")
+}
+
+func requireSignedHeader(t *testing.T, rawURL, header string) {
+	t.Helper()
+	want := strings.ToLower(header)
+	for _, h := range signedHeaders(t, rawURL) {
+		if h == want {
+			return
+		}
+	}
+	t.Errorf("expected %q among signed headers of %s", header, rawURL)
+}
+
+func TestPresignPutSignsContentTypeAndDigest(t *testing.T) {
+	b := testS3Backend(t)
+	u, err := b.PresignPut(context.Background(), "sha256:abc", 123, "application/vnd.ollama.image.model", Encryption{}, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignPut: %v", err)
+	}
+	requireSignedHeader(t, u, "content-type")
+	requireSignedHeader(t, u, digestMetaHeader)
+}
+
+func TestPresignPutSignsSSES3Header(t *testing.T) {
+	b := testS3Backend(t)
+	u, err := b.PresignPut(context.Background(), "sha256:abc", 123, "application/vnd.ollama.image.model", Encryption{Mode: EncryptionSSES3}, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignPut: %v", err)
+	}
+	requireSignedHeader(t, u, "x-amz-server-side-encryption")
+}
+
+func TestPresignPutAndPartSignSSECHeaders(t *testing.T) {
+	b := testS3Backend(t)
+	key := make([]byte, 32)
+	enc := Encryption{Mode: EncryptionSSEC, Key: key}
+
+	put, err := b.PresignPut(context.Background(), "sha256:abc", 123, "application/vnd.ollama.image.model", enc, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignPut: %v", err)
+	}
+	requireSignedHeader(t, put, "x-amz-server-side-encryption-customer-algorithm")
+	requireSignedHeader(t, put, "x-amz-server-side-encryption-customer-key")
+	requireSignedHeader(t, put, "x-amz-server-side-encryption-customer-key-MD5")
+
+	part, _, err := b.PresignPart(context.Background(), "sha256:abc", "upload-id", 1, enc, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignPart: %v", err)
+	}
+	requireSignedHeader(t, part, "x-amz-server-side-encryption-customer-algorithm")
+	requireSignedHeader(t, part, "x-amz-server-side-encryption-customer-key")
+	requireSignedHeader(t, part, "x-amz-server-side-encryption-customer-key-MD5")
+}
+
+func TestPresignGetSignsSSECHeaders(t *testing.T) {
+	b := testS3Backend(t)
+	key := make([]byte, 32)
+	u, err := b.PresignGet(context.Background(), "sha256:abc", Encryption{Mode: EncryptionSSEC, Key: key}, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+	requireSignedHeader(t, u, "x-amz-server-side-encryption-customer-algorithm")
+}
+
+func TestAddSSEHeadersRejectsShortSSECKey(t *testing.T) {
+	b := testS3Backend(t)
+	_, err := b.PresignPut(context.Background(), "sha256:abc", 123, "application/vnd.ollama.image.model", Encryption{Mode: EncryptionSSEC, Key: []byte("too-short")}, time.Minute)
+	if err == nil {
+		t.Fatal("expected error for short SSE-C key, got nil")
+	}
+}
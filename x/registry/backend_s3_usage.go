@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// GetUsage and AppendUsage give S3Backend the UsageBackend capability.
+// Usage is stored as a ledger of immutable per-charge entries rather than
+// a single mutable counter: S3's PUT has no portable If-Match
+// precondition in this SDK, so a shared counter updated via
+// read-modify-write would be racy under concurrent charges. Appending a
+// new, uniquely-keyed object needs no precondition at all, since no two
+// callers ever target the same key.
+func (b *S3Backend) GetUsage(ctx context.Context, tenant, period string) (Usage, error) {
+	u := Usage{Tenant: tenant, Period: period}
+	prefix := usagePrefix(tenant, period)
+	for obj := range b.client.ListObjects(ctx, b.bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if obj.Err != nil {
+			return Usage{}, obj.Err
+		}
+		data, err := b.getObject(ctx, obj.Key)
+		if err != nil {
+			return Usage{}, err
+		}
+		var entry Usage
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return Usage{}, err
+		}
+		u.BytesStored += entry.BytesStored
+		u.Requests += entry.Requests
+	}
+	return u, nil
+}
+
+// AppendUsage writes deltaBytes and deltaRequests as a new entry under
+// tenant's billing period, keyed by a random ID so concurrent charges
+// never collide.
+func (b *S3Backend) AppendUsage(ctx context.Context, tenant, period string, deltaBytes, deltaRequests int64) error {
+	entry := Usage{Tenant: tenant, Period: period, BytesStored: deltaBytes, Requests: deltaRequests}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	id, err := randomUsageEntryID()
+	if err != nil {
+		return err
+	}
+	key := usagePrefix(tenant, period) + id + ".json"
+	_, err = b.client.PutObject(ctx, b.bucket, key, bytesReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: "application/json",
+	})
+	return err
+}
+
+func randomUsageEntryID() (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", fmt.Errorf("generate usage entry id: %w", err)
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Operation identifies which rate budget a Limiter call applies against.
+type Operation string
+
+// Operations a Limiter can be asked to budget.
+const (
+	OpPush Operation = "push"
+	OpPull Operation = "pull"
+)
+
+// Decision is the outcome of a Limiter.Allow call.
+type Decision struct {
+	// Throttled reports whether the tenant is over its steady-state rate
+	// budget for this operation. A throttled request still proceeds, but
+	// Server responds more conservatively: shorter presigned URL TTLs
+	// and fewer parallel parts, so a client backs off rather than being
+	// handed a flood of work it can't use within budget anyway.
+	Throttled bool
+}
+
+// Limiter enforces per-tenant rate limits on registry operations. It is
+// deliberately separate from per-tenant storage quotas (tracked via
+// UsageBackend): a Limiter governs the rate of transfer, not the total
+// amount ever stored.
+type Limiter interface {
+	// Allow reports the rate-limit decision for tenant performing op
+	// against n bytes of data (the sum of layer sizes for a push, or of
+	// blob sizes for a pull).
+	Allow(ctx context.Context, tenant string, op Operation, n int64) (Decision, error)
+}
+
+// RateLimits configures the token-bucket budget a TokenBucketLimiter
+// enforces for one Operation. A zero value means unlimited.
+type RateLimits struct {
+	BytesPerSecond    float64
+	RequestsPerSecond float64
+}
+
+// TokenBucketLimiter is an in-memory, per-process Limiter keyed by
+// (tenant, operation). It's the right default for a single-replica
+// registry. A multi-replica deployment should instead back Server.Limiter
+// with a Redis-backed implementation (e.g. one built on the Redis
+// CL.THROTTLE / token-bucket Lua pattern) so replicas share rate state;
+// Limits is exported so such an implementation can reuse the same
+// per-operation configuration shape.
+type TokenBucketLimiter struct {
+	Limits map[Operation]RateLimits
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucketPair
+}
+
+type bucketKey struct {
+	tenant string
+	op     Operation
+}
+
+type bucketPair struct {
+	bytes    *rate.Limiter
+	requests *rate.Limiter
+}
+
+// NewTokenBucketLimiter returns a TokenBucketLimiter enforcing limits,
+// one token bucket pair per (tenant, operation) seen.
+func NewTokenBucketLimiter(limits map[Operation]RateLimits) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		Limits:  limits,
+		buckets: make(map[bucketKey]*bucketPair),
+	}
+}
+
+func (l *TokenBucketLimiter) pairFor(tenant string, op Operation) *bucketPair {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := bucketKey{tenant, op}
+	p, ok := l.buckets[key]
+	if ok {
+		return p
+	}
+	limits := l.Limits[op]
+	p = &bucketPair{
+		bytes:    rate.NewLimiter(rateLimit(limits.BytesPerSecond), burstFor(limits.BytesPerSecond)),
+		requests: rate.NewLimiter(rateLimit(limits.RequestsPerSecond), burstFor(limits.RequestsPerSecond)),
+	}
+	l.buckets[key] = p
+	return p
+}
+
+// rateLimit translates a zero-means-unlimited float64 into the
+// golang.org/x/time/rate representation of "unlimited".
+func rateLimit(perSecond float64) rate.Limit {
+	if perSecond <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(perSecond)
+}
+
+// burstFor sizes a bucket's burst to one second of budget, with a floor
+// so a small requests/sec limit still has at least one token to spend.
+func burstFor(perSecond float64) int {
+	if perSecond <= 0 {
+		return math.MaxInt32
+	}
+	return max(int(perSecond), 1)
+}
+
+func (l *TokenBucketLimiter) Allow(ctx context.Context, tenant string, op Operation, n int64) (Decision, error) {
+	p := l.pairFor(tenant, op)
+	now := time.Now()
+	// AllowN leaves the bucket untouched when a request doesn't fit, so a
+	// single over-budget call never drains tokens a later, smaller call
+	// could have spent; Throttled just means the tenant is spending
+	// faster than its budget refills right now, not that the bucket is
+	// empty afterward.
+	reqOK := p.requests.AllowN(now, 1)
+	byteOK := p.bytes.AllowN(now, int(cmp.Or(n, 1)))
+	return Decision{Throttled: !reqOK || !byteOK}, nil
+}
@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTokenBucketLimiterAllowsWithinBudget(t *testing.T) {
+	l := NewTokenBucketLimiter(map[Operation]RateLimits{
+		OpPush: {BytesPerSecond: 1000, RequestsPerSecond: 10},
+	})
+	d, err := l.Allow(context.Background(), "tenant-a", OpPush, 100)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if d.Throttled {
+		t.Error("Allow reported Throttled for a request well within budget")
+	}
+}
+
+func TestTokenBucketLimiterThrottlesOverBudget(t *testing.T) {
+	l := NewTokenBucketLimiter(map[Operation]RateLimits{
+		OpPush: {BytesPerSecond: 100, RequestsPerSecond: 10},
+	})
+	// The byte bucket's burst is sized to one second of budget (100
+	// bytes); a single request for far more than that can't fit no
+	// matter how empty the bucket is.
+	d, err := l.Allow(context.Background(), "tenant-a", OpPush, 1_000_000)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if !d.Throttled {
+		t.Error("Allow did not report Throttled for a request far exceeding the byte budget")
+	}
+}
+
+func TestTokenBucketLimiterIsUnlimitedByDefault(t *testing.T) {
+	l := NewTokenBucketLimiter(map[Operation]RateLimits{})
+	d, err := l.Allow(context.Background(), "tenant-a", OpPull, 1_000_000_000)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if d.Throttled {
+		t.Error("Allow reported Throttled for an Operation with no configured RateLimits")
+	}
+}
+
+func TestTokenBucketLimiterBucketsArePerTenantAndOperation(t *testing.T) {
+	l := NewTokenBucketLimiter(map[Operation]RateLimits{
+		OpPush: {BytesPerSecond: 100, RequestsPerSecond: 10},
+	})
+	ctx := context.Background()
+	if _, err := l.Allow(ctx, "tenant-a", OpPush, 100); err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	// tenant-b's bucket for the same operation is untouched by
+	// tenant-a's spend.
+	d, err := l.Allow(ctx, "tenant-b", OpPush, 100)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if d.Throttled {
+		t.Error("Allow reported Throttled for a different tenant's independent bucket")
+	}
+}
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"time"
+)
+
+// Defaults for Server's lifecycle knobs.
+const (
+	DefaultAbortMultipartAfter = 7 * 24 * time.Hour
+	DefaultOrphanBlobTTL       = 30 * 24 * time.Hour
+	DefaultReconcileInterval   = time.Hour
+)
+
+// Start installs the backend's lifecycle rules, if it supports them, and
+// launches the background reconciler that tags orphaned blobs so the
+// lifecycle rule can sweep them. It returns once the lifecycle rules are
+// installed; the reconciler keeps running until ctx is canceled.
+func (s *Server) Start(ctx context.Context) error {
+	lb, ok := s.backend.(LifecycleBackend)
+	if !ok {
+		return nil
+	}
+
+	cfg := LifecycleConfig{
+		AbortMultipartAfter: cmp.Or(s.AbortMultipartAfter, DefaultAbortMultipartAfter),
+		OrphanBlobTTL:       cmp.Or(s.OrphanBlobTTL, DefaultOrphanBlobTTL),
+	}
+	if err := lb.EnsureLifecycle(ctx, cfg); err != nil {
+		return err
+	}
+
+	go s.reconcileLoop(ctx, lb)
+	return nil
+}
+
+func (s *Server) reconcileLoop(ctx context.Context, lb LifecycleBackend) {
+	interval := cmp.Or(s.ReconcileInterval, DefaultReconcileInterval)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := s.reconcileOnce(ctx, lb); err != nil {
+			log.Printf("error: reconcile orphan blobs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// reconcileOnce tags every stored blob that no manifest references as an
+// orphan, so the bucket lifecycle rule installed by Start can expire it
+// once it's old enough (see LifecycleConfig.OrphanBlobTTL), and clears
+// that tag from every blob that is referenced. The untag step matters
+// because a blob is briefly
+// unreferenced during the window between its own upload completing and
+// the client's later handlePush call that commits the manifest
+// referencing it (multi-layer pushes upload blobs before the manifest
+// exists) — without it, a blob tagged orphan mid-push would stay tagged
+// forever and eventually be swept by the lifecycle rule despite being
+// live.
+//
+// UntagOrphan is called for every referenced digest each cycle, not just
+// ones actually carrying the tag: S3 has no cheap way to list only
+// tagged objects, so telling the two cases apart ahead of time costs a
+// tag read per blob anyway, the same as the blind clear it would be
+// saving. On a registry with very large blob counts this trades a
+// Lifecycle sweep's worst case (an active manifest's blob deleted out
+// from under it) for a steady, bounded per-cycle tagging API cost,
+// which is the tradeoff worth making.
+func (s *Server) reconcileOnce(ctx context.Context, lb LifecycleBackend) error {
+	referenced, err := lb.ListReferencedDigests(ctx)
+	if err != nil {
+		return err
+	}
+	digests, err := lb.ListBlobDigests(ctx)
+	if err != nil {
+		return err
+	}
+	for _, digest := range digests {
+		if _, ok := referenced[digest]; ok {
+			if err := lb.UntagOrphan(ctx, digest); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := lb.TagOrphan(ctx, digest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
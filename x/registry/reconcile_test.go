@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeLifecycleBackend is an in-memory LifecycleBackend for exercising
+// reconcileOnce without a real bucket.
+type fakeLifecycleBackend struct {
+	blobs      map[string]bool // digest -> exists
+	referenced map[string]struct{}
+	orphaned   map[string]bool // digest -> currently tagged orphan
+}
+
+func newFakeLifecycleBackend() *fakeLifecycleBackend {
+	return &fakeLifecycleBackend{
+		blobs:      make(map[string]bool),
+		referenced: make(map[string]struct{}),
+		orphaned:   make(map[string]bool),
+	}
+}
+
+func (f *fakeLifecycleBackend) EnsureLifecycle(ctx context.Context, cfg LifecycleConfig) error {
+	return nil
+}
+
+func (f *fakeLifecycleBackend) ListBlobDigests(ctx context.Context) ([]string, error) {
+	var digests []string
+	for d := range f.blobs {
+		digests = append(digests, d)
+	}
+	return digests, nil
+}
+
+func (f *fakeLifecycleBackend) ListReferencedDigests(ctx context.Context) (map[string]struct{}, error) {
+	referenced := make(map[string]struct{}, len(f.referenced))
+	for d := range f.referenced {
+		referenced[d] = struct{}{}
+	}
+	return referenced, nil
+}
+
+func (f *fakeLifecycleBackend) TagOrphan(ctx context.Context, digest string) error {
+	f.orphaned[digest] = true
+	return nil
+}
+
+func (f *fakeLifecycleBackend) UntagOrphan(ctx context.Context, digest string) error {
+	delete(f.orphaned, digest)
+	return nil
+}
+
+func TestReconcileOnceTagsUnreferencedBlobs(t *testing.T) {
+	lb := newFakeLifecycleBackend()
+	lb.blobs["sha256:unreferenced"] = true
+
+	s := &Server{}
+	if err := s.reconcileOnce(context.Background(), lb); err != nil {
+		t.Fatalf("reconcileOnce: %v", err)
+	}
+	if !lb.orphaned["sha256:unreferenced"] {
+		t.Error("reconcileOnce did not tag an unreferenced blob as orphan")
+	}
+}
+
+func TestReconcileOnceLeavesReferencedBlobsUntagged(t *testing.T) {
+	lb := newFakeLifecycleBackend()
+	lb.blobs["sha256:referenced"] = true
+	lb.referenced["sha256:referenced"] = struct{}{}
+
+	s := &Server{}
+	if err := s.reconcileOnce(context.Background(), lb); err != nil {
+		t.Fatalf("reconcileOnce: %v", err)
+	}
+	if lb.orphaned["sha256:referenced"] {
+		t.Error("reconcileOnce tagged a referenced blob as orphan")
+	}
+}
+
+func TestReconcileOnceUntagsABlobThatBecameReferenced(t *testing.T) {
+	lb := newFakeLifecycleBackend()
+	lb.blobs["sha256:was-orphaned"] = true
+	lb.orphaned["sha256:was-orphaned"] = true // tagged orphan by a prior cycle
+
+	// The blob's manifest lands between reconcile cycles.
+	lb.referenced["sha256:was-orphaned"] = struct{}{}
+
+	s := &Server{}
+	if err := s.reconcileOnce(context.Background(), lb); err != nil {
+		t.Fatalf("reconcileOnce: %v", err)
+	}
+	if lb.orphaned["sha256:was-orphaned"] {
+		t.Error("reconcileOnce left a now-referenced blob tagged orphan; the lifecycle rule could still sweep it")
+	}
+}
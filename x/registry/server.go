@@ -5,16 +5,18 @@ import (
 	"bytes"
 	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
-	"net/url"
 	"path"
-	"strconv"
 	"time"
 
-	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
+	"golang.org/x/crypto/hkdf"
+
 	"github.com/ollama/ollama/x/client/ollama"
 	"github.com/ollama/ollama/x/model"
 	"github.com/ollama/ollama/x/oweb"
@@ -27,13 +29,95 @@ const (
 	DefaultUploadChunkSize = 50 * 1024 * 1024
 )
 
+// Driver names accepted by Config.Driver.
+const (
+	DriverS3    = "s3"
+	DriverGCS   = "gcs"
+	DriverAzure = "azure"
+	DriverOSS   = "oss"
+)
+
+// Config selects and configures the Backend a Server stores blobs and
+// manifests in.
+type Config struct {
+	Driver string // one of Driver*
+
+	S3    S3Config
+	GCS   GCSConfig
+	Azure AzureConfig
+	OSS   OSSConfig
+}
+
 type Server struct {
 	UploadChunkSize int64 // default is DefaultUploadChunkSize
-	minioClient     *minio.Client
+
+	// AbortMultipartAfter, OrphanBlobTTL, and ReconcileInterval
+	// configure the lifecycle rules and reconciler Start installs, if
+	// the backend supports them. Zero means use the Default* value.
+	AbortMultipartAfter time.Duration
+	OrphanBlobTTL       time.Duration
+	ReconcileInterval   time.Duration
+
+	// EncryptionMode selects how newly stored blobs and manifests are
+	// encrypted. The zero value is EncryptionNone.
+	EncryptionMode EncryptionMode
+
+	// MasterKey is required when EncryptionMode is EncryptionSSEC and
+	// unused otherwise. Server never hands this key to
+	// a backend directly; it derives a distinct per-blob key from it via
+	// HKDF, keyed on the blob's digest, so a leaked per-blob key can't be
+	// used to derive any other blob's key.
+	MasterKey []byte
+
+	// PreviousMasterKey, if set, is the master key blobs were last
+	// encrypted under. handleKeyRotation uses it to re-derive each
+	// blob's old per-blob key so it can hand the backend both the old
+	// and new Encryption for RotateKey.
+	PreviousMasterKey []byte
+
+	// Limiter, if set, is consulted before a push or pull mints any
+	// presigned URLs. A nil Limiter means unlimited.
+	Limiter Limiter
+
+	// MonthlyByteQuota, if nonzero, is the maximum number of bytes a
+	// tenant may push in one billing period. Enforcing it requires the
+	// backend to implement UsageBackend; against a backend that
+	// doesn't, quota checks are skipped rather than failing every push.
+	MonthlyByteQuota int64
+
+	backend Backend
+}
+
+// New returns a Server that stores blobs and manifests using the backend
+// selected by cfg.Driver.
+func New(ctx context.Context, cfg Config) (*Server, error) {
+	var (
+		b   Backend
+		err error
+	)
+	switch cfg.Driver {
+	case DriverS3:
+		b, err = NewS3Backend(cfg.S3)
+	case DriverGCS:
+		b, err = NewGCSBackend(ctx, cfg.GCS)
+	case DriverAzure:
+		b, err = NewAzureBackend(cfg.Azure)
+	case DriverOSS:
+		b, err = NewOSSBackend(cfg.OSS)
+	default:
+		return nil, fmt.Errorf("registry: unknown driver %q", cfg.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Server{backend: b}, nil
 }
 
-func New(mc *minio.Client) *Server {
-	return &Server{minioClient: mc}
+// NewWithBackend returns a Server that stores blobs and manifests using
+// the given Backend directly, bypassing driver selection. It exists
+// mainly for tests that supply a fake or in-memory Backend.
+func NewWithBackend(b Backend) *Server {
+	return &Server{backend: b}
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -56,18 +140,96 @@ func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) error {
 		return s.handlePush(w, r)
 	case "/v1/pull":
 		return s.handlePull(w, r)
+	case "/v1/resolve":
+		return s.handleResolve(w, r)
+	case "/v1/admin/rotate-key":
+		return s.handleKeyRotation(w, r)
+	case "/v1/usage":
+		return s.handleUsage(w, r)
 	default:
 		return oweb.ErrNotFound
 	}
 }
 
+// tenantFor derives the quota and rate-limit tenant for a model name. A
+// name is namespaced (e.g. "library/llama3:latest"); the leading
+// namespace component is the natural billing boundary, the same way a
+// container registry scopes pushes per organization.
+func tenantFor(mp model.Name) string {
+	parts := mp.Parts()
+	if len(parts) == 0 {
+		return ""
+	}
+	return parts[0]
+}
+
+// checkQuota returns oweb.Invalid if pushing pendingBytes more would put
+// tenant over s.MonthlyByteQuota for the current billing period. It's a
+// no-op if no quota is configured or the backend can't track usage.
+func (s *Server) checkQuota(ctx context.Context, tenant string, pendingBytes int64) error {
+	if s.MonthlyByteQuota <= 0 || pendingBytes == 0 {
+		return nil
+	}
+	ub, ok := s.backend.(UsageBackend)
+	if !ok {
+		return nil
+	}
+	u, err := ub.GetUsage(ctx, tenant, currentPeriod(time.Now()))
+	if err != nil {
+		return err
+	}
+	if u.BytesStored+pendingBytes > s.MonthlyByteQuota {
+		return oweb.Invalid("manifest", tenant, fmt.Sprintf("would exceed monthly quota of %d bytes", s.MonthlyByteQuota))
+	}
+	return nil
+}
+
 func (s *Server) uploadChunkSize() int64 {
 	return cmp.Or(s.UploadChunkSize, DefaultUploadChunkSize)
 }
 
+func (s *Server) encryptionMode() EncryptionMode {
+	return cmp.Or(s.EncryptionMode, EncryptionNone)
+}
+
+// encryptionFor returns the Encryption a backend call storing or reading
+// the blob at digest should use. For EncryptionSSEC it derives a key
+// unique to digest from masterKey via HKDF, rather than reusing masterKey
+// directly for every blob.
+func encryptionFor(mode EncryptionMode, masterKey []byte, digest string) (Encryption, error) {
+	enc := Encryption{Mode: mode}
+	if mode == EncryptionSSEC {
+		key, err := deriveSSECKey(masterKey, digest)
+		if err != nil {
+			return Encryption{}, err
+		}
+		enc.Key = key
+	}
+	return enc, nil
+}
+
+func (s *Server) encryptionForBlob(digest string) (Encryption, error) {
+	return encryptionFor(s.encryptionMode(), s.MasterKey, digest)
+}
+
+// deriveSSECKey derives a 32-byte AES-256 key for digest from masterKey
+// using HKDF-SHA256, with digest as the info parameter. Deriving per-blob
+// instead of reusing masterKey directly means a single leaked blob key
+// never exposes masterKey or any other blob's key.
+func deriveSSECKey(masterKey []byte, digest string) ([]byte, error) {
+	if len(masterKey) == 0 {
+		return nil, fmt.Errorf("registry: sse-c requires a master key")
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, masterKey, nil, []byte(digest)), key); err != nil {
+		return nil, fmt.Errorf("derive sse-c key: %w", err)
+	}
+	return key, nil
+}
+
 func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) error {
-	const bucketTODO = "test"
-	const minimumMultipartSize = 5 * 1024 * 1024 // S3 spec
+	const minimumMultipartSize = 5 * 1024 * 1024 // S3 spec; no smaller than other backends require, so safe everywhere
+	const timeToStartUpload = 15 * time.Minute
 
 	pr, err := oweb.DecodeUserJSON[apitype.PushRequest]("", r.Body)
 	if err != nil {
@@ -78,128 +240,192 @@ func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) error {
 	if !mp.IsComplete() {
 		return oweb.Invalid("name", pr.Name, "must be complete")
 	}
+	tenant := tenantFor(mp)
 
 	m, err := oweb.DecodeUserJSON[apitype.Manifest]("manifest", bytes.NewReader(pr.Manifest))
 	if err != nil {
 		return err
 	}
 
-	mcc := &minio.Core{Client: s.mc()}
-	// TODO(bmizerany): complete uploads before stats for any with ETag
+	// Group the client's reported parts by the (digest, uploadID) pair
+	// encoded in their handle so we can complete each multipart upload
+	// once all of its parts have come back. The handle is
+	// self-describing, so this works across requests without the
+	// server remembering anything about the upload in between.
+	type uploadKey struct{ digest, uploadID string }
+	completePartsByUpload := make(map[uploadKey][]Part)
+	for _, cp := range pr.CompleteParts {
+		var env handleEnvelope
+		if err := decodeHandle(cp.Handle, &env); err != nil {
+			return oweb.Invalid("handle", cp.Handle, "malformed handle")
+		}
+		if cp.ETag == "" {
+			return oweb.Missing("etag")
+		}
+		key := uploadKey{env.Digest, env.UploadID}
+		completePartsByUpload[key] = append(completePartsByUpload[key], Part{
+			Handle: env.Inner,
+			ETag:   cp.ETag,
+		})
+	}
 
-	type completeParts struct {
-		key   string
-		parts []minio.CompletePart
+	layersByDigest := make(map[string]apitype.Layer, len(m.Layers))
+	for _, l := range m.Layers {
+		layersByDigest[l.Digest] = l
 	}
 
-	completePartsByUploadID := make(map[string]completeParts)
-	for _, mcp := range pr.CompleteParts {
-		// parse the URL
-		u, err := url.Parse(mcp.URL)
+	usageChargedDigests := make(map[string]bool, len(completePartsByUpload))
+	for key, parts := range completePartsByUpload {
+		if err := s.backend.CompleteMultipart(r.Context(), key.digest, key.uploadID, parts); err != nil {
+			return err
+		}
+		info, err := s.backend.StatBlob(r.Context(), key.digest)
 		if err != nil {
 			return err
 		}
+		if want := layersByDigest[key.digest].MediaType; info.ContentType != string(want) {
+			return oweb.Invalid("contentType", info.ContentType, "does not match manifest media type "+string(want))
+		}
+		if ub, ok := s.backend.(UsageBackend); ok {
+			if err := chargeUsage(r.Context(), ub, tenant, time.Now(), info.Size, 1); err != nil {
+				return err
+			}
+		}
+		usageChargedDigests[key.digest] = true
+	}
 
-		q := u.Query()
-
-		// Check if this is a part upload, if not, skip
-		uploadID := q.Get("uploadId")
-		if uploadID == "" {
-			// not a part upload
+	// Find which layers still need uploading before minting any
+	// Requirements, both to size the quota check below against only the
+	// bytes actually being pushed and to avoid calling StatBlob twice
+	// per layer. This is also the only place a single-part blob (one
+	// that was small enough to skip the multipart path entirely) is
+	// ever seen again after its PUT: there's no CompleteParts-style
+	// acknowledgment for a single-part upload, so its Content-Type has
+	// to be checked against the manifest here, and its usage charged
+	// here, rather than in the completePartsByUpload loop above, which
+	// only ever sees multipart uploads.
+	var pending []apitype.Layer
+	var pendingBytes int64
+	for _, l := range m.Layers {
+		if l.Size == 0 {
 			continue
 		}
-
-		// PartNumber is required
-		queryPartNumber := q.Get("partNumber")
-		partNumber, err := strconv.Atoi(queryPartNumber)
+		if l.MediaType == "" {
+			return oweb.Missing("mediaType")
+		}
+		info, err := s.backend.StatBlob(r.Context(), l.Digest)
 		if err != nil {
-			return oweb.Invalid("partNumber", queryPartNumber, "invalid or missing PartNumber")
+			if !errors.Is(err, ErrBlobNotFound) {
+				return err
+			}
+			pending = append(pending, l)
+			pendingBytes += l.Size
+			continue
 		}
-
-		// ETag is required
-		if mcp.ETag == "" {
-			return oweb.Missing("etag")
+		if info.ContentType != string(l.MediaType) {
+			return oweb.Invalid("contentType", info.ContentType, "does not match manifest media type "+string(l.MediaType))
+		}
+		// Single-part blobs have no completion handshake to key a
+		// one-time charge off of, so every stored layer is charged here
+		// unless the loop above already charged it in this request. That
+		// includes blobs uploaded by multipart in an earlier request and
+		// blobs shared with another manifest, so this can over-count
+		// bytes for reused layers across repeated pushes of the same
+		// manifest. That's an acceptable tradeoff against the
+		// alternative of never charging single-part blobs at all.
+		if !usageChargedDigests[l.Digest] {
+			if ub, ok := s.backend.(UsageBackend); ok {
+				if err := chargeUsage(r.Context(), ub, tenant, time.Now(), info.Size, 1); err != nil {
+					return err
+				}
+			}
 		}
+	}
 
-		cp := completePartsByUploadID[uploadID]
-		cp.key = u.Path
-		cp.parts = append(cp.parts, minio.CompletePart{
-			PartNumber: partNumber,
-			ETag:       mcp.ETag,
-		})
-		completePartsByUploadID[uploadID] = cp
+	if err := s.checkQuota(r.Context(), tenant, pendingBytes); err != nil {
+		return err
 	}
 
-	for uploadID, cp := range completePartsByUploadID {
-		var zeroOpts minio.PutObjectOptions
-		_, err := mcc.CompleteMultipartUpload(r.Context(), bucketTODO, cp.key, uploadID, cp.parts, zeroOpts)
+	ttl := timeToStartUpload
+	chunkSize := s.uploadChunkSize()
+	if s.Limiter != nil {
+		decision, err := s.Limiter.Allow(r.Context(), tenant, OpPush, pendingBytes)
 		if err != nil {
-			var e minio.ErrorResponse
-			if errors.As(err, &e) && e.Code == "NoSuchUpload" {
-				return oweb.Invalid("uploadId", uploadID, "unknown uploadId")
-			}
 			return err
 		}
+		if decision.Throttled {
+			// Over the rate budget: hand out less generous URLs and
+			// fewer, larger parts rather than rejecting outright, so a
+			// well-behaved client naturally backs off instead of
+			// retrying a flood of short-lived URLs.
+			ttl /= 2
+			chunkSize *= 2
+		}
 	}
 
 	var requirements []apitype.Requirement
-	for _, l := range m.Layers {
+	for _, l := range pending {
 		// TODO(bmizerany): do in parallel
-		if l.Size == 0 {
-			continue
-		}
-
-		// TODO(bmizerany): "global" throttle of rate of transfer
-		pushed, err := s.statObject(r.Context(), l.Digest)
+		enc, err := s.encryptionForBlob(l.Digest)
 		if err != nil {
 			return err
 		}
-		if !pushed {
-			key := path.Join("blobs", l.Digest)
-			if l.Size < minimumMultipartSize {
-				// single part upload
-				signedURL, err := s.mc().PresignedPutObject(r.Context(), bucketTODO, key, 15*time.Minute)
+		if l.Size < minimumMultipartSize {
+			// single part upload
+			signedURL, err := s.backend.PresignPut(r.Context(), l.Digest, l.Size, string(l.MediaType), enc, ttl)
+			if err != nil {
+				return err
+			}
+			requirements = append(requirements, apitype.Requirement{
+				Digest: l.Digest,
+				Size:   l.Size,
+				URL:    signedURL,
+			})
+		} else {
+			uploadID, err := s.backend.NewMultipartUpload(r.Context(), l.Digest, string(l.MediaType), enc)
+			if err != nil {
+				return err
+			}
+			for partNumber, c := range upload.Chunks(l.Size, chunkSize) {
+				signedURL, inner, err := s.backend.PresignPart(r.Context(), l.Digest, uploadID, partNumber, enc, ttl)
 				if err != nil {
 					return err
 				}
+				handle := encodeHandle(handleEnvelope{
+					Digest:   l.Digest,
+					UploadID: uploadID,
+					Inner:    inner,
+				})
+
 				requirements = append(requirements, apitype.Requirement{
 					Digest: l.Digest,
-					Size:   l.Size,
-					URL:    signedURL.String(),
+					Offset: c.Offset,
+					Size:   c.N,
+					URL:    signedURL,
+					Handle: handle,
 				})
-			} else {
-				uploadID, err := mcc.NewMultipartUpload(r.Context(), bucketTODO, key, minio.PutObjectOptions{})
-				if err != nil {
-					return err
-				}
-				for partNumber, c := range upload.Chunks(l.Size, s.uploadChunkSize()) {
-					const timeToStartUpload = 15 * time.Minute
-
-					signedURL, err := s.mc().Presign(r.Context(), "PUT", bucketTODO, key, timeToStartUpload, url.Values{
-						"uploadId":   []string{uploadID},
-						"partNumber": []string{strconv.Itoa(partNumber)},
-					})
-					if err != nil {
-						return err
-					}
-
-					requirements = append(requirements, apitype.Requirement{
-						Digest: l.Digest,
-						Offset: c.Offset,
-						Size:   c.N,
-						URL:    signedURL.String(),
-					})
-				}
 			}
 		}
 	}
 
 	if len(requirements) == 0 {
-		// Commit the manifest
-		body := bytes.NewReader(pr.Manifest)
-		path := path.Join("manifests", path.Join(mp.Parts()...))
-		_, err := s.mc().PutObject(r.Context(), bucketTODO, path, body, int64(len(pr.Manifest)), minio.PutObjectOptions{})
-		if err != nil {
+		// Commit the manifest. Manifests always use bucket-level
+		// encryption rather than per-blob SSE-C keys: GetManifest has
+		// no way to be told which key to read a manifest back with,
+		// so EncryptionSSEC degrades to EncryptionSSES3 here.
+		name := path.Join(mp.Parts()...)
+		manifestEncMode := s.encryptionMode()
+		if manifestEncMode == EncryptionSSEC {
+			manifestEncMode = EncryptionSSES3
+		}
+		meta := ManifestMetadata{
+			ContentType: apitype.MediaTypeManifest,
+			UserMetadata: map[string]string{
+				"ollama-name": pr.Name,
+			},
+			Encryption: Encryption{Mode: manifestEncMode},
+		}
+		if err := s.backend.PutManifest(r.Context(), name, pr.Manifest, meta); err != nil {
 			return err
 		}
 	}
@@ -207,39 +433,166 @@ func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) error {
 	return oweb.EncodeJSON(w, &apitype.PushResponse{Requirements: requirements})
 }
 
+// pullURLTTL is how long a presigned blob GET minted for a pull stays
+// valid. It accepts ordinary HTTP Range requests, so a client can resume
+// a partial download by reissuing the same URL within the window.
+const pullURLTTL = 15 * time.Minute
+
 func (s *Server) handlePull(w http.ResponseWriter, r *http.Request) error {
-	// lookup manifest
-	panic("TODO")
-}
+	pr, err := oweb.DecodeUserJSON[apitype.PullRequest]("", r.Body)
+	if err != nil {
+		return err
+	}
 
-func (s *Server) statObject(ctx context.Context, digest string) (pushed bool, err error) {
-	// HEAD the object
-	path := path.Join("blobs", digest)
-	_, err = s.mc().StatObject(ctx, "test", path, minio.StatObjectOptions{})
+	m, data, err := s.getManifest(r.Context(), pr.Name)
 	if err != nil {
-		if isNoSuchKey(err) {
-			err = nil
+		return err
+	}
+
+	mp := model.ParseName(pr.Name)
+	tenant := tenantFor(mp)
+	ttl := pullURLTTL
+	if s.Limiter != nil {
+		var pullBytes int64
+		for _, l := range m.Layers {
+			pullBytes += l.Size
+		}
+		decision, err := s.Limiter.Allow(r.Context(), tenant, OpPull, pullBytes)
+		if err != nil {
+			return err
+		}
+		if decision.Throttled {
+			ttl /= 2
 		}
-		return false, err
 	}
-	return true, nil
-}
 
-func isNoSuchKey(err error) bool {
-	var e minio.ErrorResponse
-	return errors.As(err, &e) && e.Code == "NoSuchKey"
+	layers := make([]apitype.PullLayer, 0, len(m.Layers))
+	for _, l := range m.Layers {
+		if l.Size == 0 {
+			continue
+		}
+		enc, err := s.encryptionForBlob(l.Digest)
+		if err != nil {
+			return err
+		}
+		signedURL, err := s.backend.PresignGet(r.Context(), l.Digest, enc, ttl)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, apitype.PullLayer{Digest: l.Digest, Size: l.Size, URL: signedURL})
+	}
+
+	return oweb.EncodeJSON(w, &apitype.PullResponse{
+		Manifest: m,
+		Digest:   digestOf(data),
+		Layers:   layers,
+	})
 }
 
-func (s *Server) mc() *minio.Client {
-	if s.minioClient != nil {
-		return s.minioClient
+// handleResolve returns a manifest and its digest without minting any
+// blob URLs, for callers that only need a cheap tag lookup.
+func (s *Server) handleResolve(w http.ResponseWriter, r *http.Request) error {
+	pr, err := oweb.DecodeUserJSON[apitype.ResolveRequest]("", r.Body)
+	if err != nil {
+		return err
+	}
+
+	m, data, err := s.getManifest(r.Context(), pr.Name)
+	if err != nil {
+		return err
 	}
-	mc, err := minio.New("localhost:9000", &minio.Options{
-		Creds:  credentials.NewStaticV4("minioadmin", "minioadmin", ""),
-		Secure: false,
+
+	return oweb.EncodeJSON(w, &apitype.ResolveResponse{
+		Manifest: m,
+		Digest:   digestOf(data),
 	})
+}
+
+// handleKeyRotation re-encrypts a single blob from PreviousMasterKey to
+// MasterKey. It returns oweb.ErrNotFound if the backend doesn't support
+// key rotation, the same way a missing route would, since from a caller's
+// perspective the capability simply isn't there.
+func (s *Server) handleKeyRotation(w http.ResponseWriter, r *http.Request) error {
+	kb, ok := s.backend.(KeyRotationBackend)
+	if !ok {
+		return oweb.ErrNotFound
+	}
+
+	rr, err := oweb.DecodeUserJSON[apitype.RotateKeyRequest]("", r.Body)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	if rr.Digest == "" {
+		return oweb.Missing("digest")
+	}
+
+	oldEnc, err := encryptionFor(s.encryptionMode(), s.PreviousMasterKey, rr.Digest)
+	if err != nil {
+		return err
+	}
+	newEnc, err := s.encryptionForBlob(rr.Digest)
+	if err != nil {
+		return err
+	}
+	if err := kb.RotateKey(r.Context(), rr.Digest, oldEnc, newEnc); err != nil {
+		return err
+	}
+
+	return oweb.EncodeJSON(w, &apitype.RotateKeyResponse{Digest: rr.Digest})
+}
+
+// handleUsage reports a tenant's storage usage for the current billing
+// period. It returns all zeros, rather than an error, if the backend
+// doesn't implement UsageBackend: the endpoint's contract is "tell me
+// what you know," not "fail unless quotas are wired up."
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) error {
+	ur, err := oweb.DecodeUserJSON[apitype.UsageRequest]("", r.Body)
+	if err != nil {
+		return err
 	}
-	return mc
+	if ur.Tenant == "" {
+		return oweb.Missing("tenant")
+	}
+
+	period := currentPeriod(time.Now())
+	resp := &apitype.UsageResponse{Tenant: ur.Tenant, Period: period}
+	if ub, ok := s.backend.(UsageBackend); ok {
+		u, err := ub.GetUsage(r.Context(), ur.Tenant, period)
+		if err != nil {
+			return err
+		}
+		resp.BytesStored = u.BytesStored
+		resp.Requests = u.Requests
+	}
+
+	return oweb.EncodeJSON(w, resp)
+}
+
+// getManifest resolves name to a manifest object, decodes it, and returns
+// both the decoded manifest and its raw bytes (needed to compute its
+// digest). It maps a missing manifest to oweb.ErrNotFound.
+func (s *Server) getManifest(ctx context.Context, name string) (apitype.Manifest, []byte, error) {
+	mp := model.ParseName(name)
+	if !mp.IsComplete() {
+		return apitype.Manifest{}, nil, oweb.Invalid("name", name, "must be complete")
+	}
+
+	data, err := s.backend.GetManifest(ctx, path.Join(mp.Parts()...))
+	if err != nil {
+		if errors.Is(err, ErrManifestNotFound) {
+			return apitype.Manifest{}, nil, oweb.ErrNotFound
+		}
+		return apitype.Manifest{}, nil, err
+	}
+
+	m, err := oweb.DecodeUserJSON[apitype.Manifest]("manifest", bytes.NewReader(data))
+	if err != nil {
+		return apitype.Manifest{}, nil, err
+	}
+	return m, data, nil
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,70 @@
+package registry
+
+import "testing"
+
+func TestEncryptionForNone(t *testing.T) {
+	enc, err := encryptionFor(EncryptionNone, nil, "sha256:abc")
+	if err != nil {
+		t.Fatalf("encryptionFor: %v", err)
+	}
+	if enc.Mode != EncryptionNone || enc.Key != nil {
+		t.Errorf("encryptionFor(EncryptionNone) = %+v, want zero Key", enc)
+	}
+}
+
+func TestEncryptionForSSES3(t *testing.T) {
+	enc, err := encryptionFor(EncryptionSSES3, nil, "sha256:abc")
+	if err != nil {
+		t.Fatalf("encryptionFor: %v", err)
+	}
+	if enc.Mode != EncryptionSSES3 || enc.Key != nil {
+		t.Errorf("encryptionFor(EncryptionSSES3) = %+v, want no derived key", enc)
+	}
+}
+
+func TestEncryptionForSSECDerivesA32ByteKey(t *testing.T) {
+	masterKey := []byte("master-key-material")
+	enc, err := encryptionFor(EncryptionSSEC, masterKey, "sha256:abc")
+	if err != nil {
+		t.Fatalf("encryptionFor: %v", err)
+	}
+	if len(enc.Key) != 32 {
+		t.Fatalf("derived key length = %d, want 32", len(enc.Key))
+	}
+}
+
+func TestEncryptionForSSECRequiresMasterKey(t *testing.T) {
+	if _, err := encryptionFor(EncryptionSSEC, nil, "sha256:abc"); err == nil {
+		t.Fatal("expected error deriving an SSE-C key with no master key, got nil")
+	}
+}
+
+func TestDeriveSSECKeyIsDeterministic(t *testing.T) {
+	masterKey := []byte("master-key-material")
+	k1, err := deriveSSECKey(masterKey, "sha256:abc")
+	if err != nil {
+		t.Fatalf("deriveSSECKey: %v", err)
+	}
+	k2, err := deriveSSECKey(masterKey, "sha256:abc")
+	if err != nil {
+		t.Fatalf("deriveSSECKey: %v", err)
+	}
+	if string(k1) != string(k2) {
+		t.Error("deriveSSECKey returned different keys for the same masterKey/digest")
+	}
+}
+
+func TestDeriveSSECKeyDiffersPerDigest(t *testing.T) {
+	masterKey := []byte("master-key-material")
+	k1, err := deriveSSECKey(masterKey, "sha256:abc")
+	if err != nil {
+		t.Fatalf("deriveSSECKey: %v", err)
+	}
+	k2, err := deriveSSECKey(masterKey, "sha256:def")
+	if err != nil {
+		t.Fatalf("deriveSSECKey: %v", err)
+	}
+	if string(k1) == string(k2) {
+		t.Error("deriveSSECKey returned the same key for two different digests; a leaked per-blob key would expose others")
+	}
+}
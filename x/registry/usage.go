@@ -0,0 +1,59 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Usage is one tenant's storage accounting for a calendar month.
+type Usage struct {
+	Tenant      string
+	Period      string // yyyymm, e.g. "202607"
+	BytesStored int64
+	Requests    int64
+}
+
+// UsageBackend is implemented by backends that can track per-tenant usage
+// counters. Server type-asserts for it; a backend that doesn't implement
+// it simply can't enforce monthly quotas or serve non-zero /v1/usage
+// responses.
+type UsageBackend interface {
+	// GetUsage returns tenant's usage totals for the given billing
+	// period, summed from every charge recorded so far, or a zero Usage
+	// if nothing has been recorded yet this period.
+	GetUsage(ctx context.Context, tenant, period string) (Usage, error)
+
+	// AppendUsage records one usage delta for tenant's billing period as
+	// its own immutable entry rather than updating a shared counter in
+	// place, so concurrent callers charging the same tenant/period never
+	// race on a read-modify-write: each charge is its own write, and
+	// GetUsage sums them.
+	AppendUsage(ctx context.Context, tenant, period string, deltaBytes, deltaRequests int64) error
+}
+
+// usagePrefix is the key prefix usage entries for tenant's billing period
+// are stored under. Each charge gets its own key beneath it so concurrent
+// writers never overwrite one another.
+func usagePrefix(tenant, period string) string {
+	return fmt.Sprintf("usage/%s/%s/", tenant, period)
+}
+
+// currentPeriod returns the yyyymm billing period now falls in.
+func currentPeriod(now time.Time) string {
+	return now.Format("200601")
+}
+
+// chargeUsage records deltaBytes and deltaRequests against tenant's usage
+// for the period now falls in. Because AppendUsage never reads before it
+// writes, concurrent charges for the same tenant/period can't silently
+// lose an increment to a lost-update race the way a compare-and-swap
+// over a single shared counter could.
+//
+// It doesn't return the tenant's updated total: for an S3-backed ledger
+// that means a GetUsage summing every entry recorded so far this period,
+// which every caller so far only ever discards. A caller that actually
+// needs the post-charge total should call GetUsage itself.
+func chargeUsage(ctx context.Context, ub UsageBackend, tenant string, now time.Time, deltaBytes, deltaRequests int64) error {
+	return ub.AppendUsage(ctx, tenant, currentPeriod(now), deltaBytes, deltaRequests)
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUsageBackend is an in-memory UsageBackend that mirrors S3Backend's
+// ledger design: AppendUsage stores an independent entry per call, and
+// GetUsage sums whatever's been appended for tenant/period.
+type fakeUsageBackend struct {
+	mu      sync.Mutex
+	entries map[string][]Usage // keyed by usagePrefix(tenant, period)
+}
+
+func newFakeUsageBackend() *fakeUsageBackend {
+	return &fakeUsageBackend{entries: make(map[string][]Usage)}
+}
+
+func (f *fakeUsageBackend) GetUsage(ctx context.Context, tenant, period string) (Usage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u := Usage{Tenant: tenant, Period: period}
+	for _, e := range f.entries[usagePrefix(tenant, period)] {
+		u.BytesStored += e.BytesStored
+		u.Requests += e.Requests
+	}
+	return u, nil
+}
+
+func (f *fakeUsageBackend) AppendUsage(ctx context.Context, tenant, period string, deltaBytes, deltaRequests int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := usagePrefix(tenant, period)
+	f.entries[key] = append(f.entries[key], Usage{Tenant: tenant, Period: period, BytesStored: deltaBytes, Requests: deltaRequests})
+	return nil
+}
+
+func TestChargeUsageAccumulates(t *testing.T) {
+	ub := newFakeUsageBackend()
+	now := time.Date(2026, 7, 29, 0, 0, 0, 0, time.UTC)
+
+	if err := chargeUsage(context.Background(), ub, "acme", now, 100, 1); err != nil {
+		t.Fatalf("chargeUsage: %v", err)
+	}
+	if err := chargeUsage(context.Background(), ub, "acme", now, 50, 1); err != nil {
+		t.Fatalf("chargeUsage: %v", err)
+	}
+
+	u, err := ub.GetUsage(context.Background(), "acme", currentPeriod(now))
+	if err != nil {
+		t.Fatalf("GetUsage: %v", err)
+	}
+	if u.BytesStored != 150 || u.Requests != 2 {
+		t.Errorf("GetUsage = %+v, want BytesStored=150 Requests=2", u)
+	}
+}
+
+func TestChargeUsageIsPerTenantAndPeriod(t *testing.T) {
+	ub := newFakeUsageBackend()
+	now := time.Date(2026, 7, 29, 0, 0, 0, 0, time.UTC)
+	otherPeriod := now.AddDate(0, 1, 0)
+
+	if err := chargeUsage(context.Background(), ub, "acme", now, 100, 1); err != nil {
+		t.Fatalf("chargeUsage: %v", err)
+	}
+	if err := chargeUsage(context.Background(), ub, "other-tenant", now, 999, 1); err != nil {
+		t.Fatalf("chargeUsage: %v", err)
+	}
+	if err := chargeUsage(context.Background(), ub, "acme", otherPeriod, 999, 1); err != nil {
+		t.Fatalf("chargeUsage: %v", err)
+	}
+
+	u, err := ub.GetUsage(context.Background(), "acme", currentPeriod(now))
+	if err != nil {
+		t.Fatalf("GetUsage: %v", err)
+	}
+	if u.BytesStored != 100 || u.Requests != 1 {
+		t.Errorf("GetUsage = %+v, want BytesStored=100 Requests=1 (isolated from other tenant/period)", u)
+	}
+}
+
+func TestChargeUsageConcurrentCallsNeverLoseAnIncrement(t *testing.T) {
+	ub := newFakeUsageBackend()
+	now := time.Date(2026, 7, 29, 0, 0, 0, 0, time.UTC)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := chargeUsage(context.Background(), ub, "acme", now, 1, 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	u, err := ub.GetUsage(context.Background(), "acme", currentPeriod(now))
+	if err != nil {
+		t.Fatalf("GetUsage: %v", err)
+	}
+	if u.BytesStored != n || u.Requests != n {
+		t.Errorf("GetUsage = %+v, want BytesStored=%d Requests=%d after %d concurrent charges", u, n, n, n)
+	}
+}
@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+)
+
+func bytesReader(b []byte) *bytes.Reader { return bytes.NewReader(b) }
+
+func readAll(r io.Reader) ([]byte, error) { return io.ReadAll(r) }
+
+// encodeHandle packs v into an opaque string suitable for returning to
+// clients as part of apitype.Requirement.Handle. Clients must treat it as
+// an opaque blob and echo it back unmodified.
+func encodeHandle(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// v is always a small, marshalable struct defined by us.
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeHandle(handle string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(handle)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// handleEnvelope wraps a backend-specific inner handle with the digest
+// and uploadID it belongs to, so a Requirement.Handle is self-describing
+// and the server doesn't need to remember, across requests, which
+// upload a client's handle came from.
+type handleEnvelope struct {
+	Digest   string
+	UploadID string
+	Inner    string
+}
+
+func encodeS3PartHandle(h s3PartHandle) string { return encodeHandle(h) }
+
+func decodeS3PartHandle(handle string) (s3PartHandle, error) {
+	var h s3PartHandle
+	err := decodeHandle(handle, &h)
+	return h, err
+}
+
+// toStringPtrMap adapts a plain string map to the map[string]*string shape
+// the Azure SDK wants for blob metadata.
+func toStringPtrMap(m map[string]string) map[string]*string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]*string, len(m))
+	for k, v := range m {
+		v := v
+		out[k] = &v
+	}
+	return out
+}
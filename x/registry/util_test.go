@@ -0,0 +1,36 @@
+package registry
+
+import "testing"
+
+func TestEncodeDecodeHandleRoundTrip(t *testing.T) {
+	want := s3PartHandle{PartNumber: 7}
+	encoded := encodeS3PartHandle(want)
+
+	got, err := decodeS3PartHandle(encoded)
+	if err != nil {
+		t.Fatalf("decodeS3PartHandle: %v", err)
+	}
+	if got != want {
+		t.Errorf("decodeS3PartHandle(%q) = %+v, want %+v", encoded, got, want)
+	}
+}
+
+func TestDecodeHandleRejectsGarbage(t *testing.T) {
+	var h s3PartHandle
+	if err := decodeHandle("not-valid-base64url!!", &h); err == nil {
+		t.Fatal("expected error decoding garbage handle, got nil")
+	}
+}
+
+func TestHandleEnvelopeRoundTrip(t *testing.T) {
+	want := handleEnvelope{Digest: "sha256:abc", UploadID: "upload-1", Inner: "inner-handle"}
+	encoded := encodeHandle(want)
+
+	var got handleEnvelope
+	if err := decodeHandle(encoded, &got); err != nil {
+		t.Fatalf("decodeHandle: %v", err)
+	}
+	if got != want {
+		t.Errorf("decodeHandle(%q) = %+v, want %+v", encoded, got, want)
+	}
+}